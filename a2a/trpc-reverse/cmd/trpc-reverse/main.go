@@ -0,0 +1,147 @@
+// Command trpc-reverse runs the A2A reverse proxy: it terminates incoming
+// message/send, message/stream, tasks/get, and tasks/cancel JSON-RPC calls
+// and forwards them to one or more upstream A2A agents, persisting task
+// state and upstream bindings in Redis so the proxy can be run as multiple
+// replicas. When configured, it authenticates requests against a JWKS
+// endpoint and enforces a per-principal, Redis-backed rate limit before
+// forwarding.
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"trpc.group/trpc-go/trpc-a2a-go/log"
+	"trpc.group/trpc-go/trpc-a2a-go/server"
+	redistaskmanager "trpc.group/trpc-go/trpc-a2a-go/taskmanager/redis"
+
+	"githib.ibm.com/snible/a2a-examples/trpc-reverse/internal/authn"
+	"githib.ibm.com/snible/a2a-examples/trpc-reverse/internal/cache"
+	"githib.ibm.com/snible/a2a-examples/trpc-reverse/internal/config"
+	"githib.ibm.com/snible/a2a-examples/trpc-reverse/internal/proxytask"
+	"githib.ibm.com/snible/a2a-examples/trpc-reverse/internal/ratelimit"
+	"githib.ibm.com/snible/a2a-examples/trpc-reverse/internal/telemetry"
+	"githib.ibm.com/snible/a2a-examples/trpc-reverse/internal/upstreampool"
+)
+
+func main() {
+	if err := run(); err != nil {
+		log.Errorf("trpc-reverse: %v", err)
+		os.Exit(1)
+	}
+}
+
+func run() error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+
+	providers, shutdownTelemetry, err := telemetry.Setup(ctx, cfg.ServiceName)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := shutdownTelemetry(shutdownCtx); err != nil {
+			log.Errorf("trpc-reverse: shutting down telemetry: %v", err)
+		}
+	}()
+
+	redisClient := redis.NewClient(&redis.Options{
+		Addr:     cfg.RedisAddr,
+		Password: cfg.RedisPassword,
+		DB:       cfg.RedisDB,
+	})
+	defer redisClient.Close()
+	telemetry.InstrumentRedis(redisClient, providers)
+
+	strategy, err := upstreampool.NewStrategy(cfg.UpstreamStrategy)
+	if err != nil {
+		return err
+	}
+	pool, err := upstreampool.New(cfg.UpstreamURLs, strategy, redisClient, upstreampool.Options{
+		BindingTTL:          cfg.BindingTTL,
+		HealthCheckInterval: cfg.HealthCheckInterval,
+	})
+	if err != nil {
+		return err
+	}
+	go pool.StartHealthChecks(ctx)
+
+	var resultCache *cache.Cache
+	if cfg.Cache.Enabled {
+		resultCache = cache.New(redisClient, cfg.Cache.TTL)
+	}
+
+	processor := proxytask.New(pool, resultCache)
+	innerTaskManager, err := redistaskmanager.NewTaskManager(redisClient, processor)
+	if err != nil {
+		return err
+	}
+	defer innerTaskManager.Close()
+	taskManager := proxytask.NewTaskManager(innerTaskManager, pool)
+
+	agentCard := server.AgentCard{
+		Name:               cfg.ServiceName,
+		Description:        "A2A reverse proxy forwarding to " + strings.Join(cfg.UpstreamURLs, ", "),
+		URL:                cfg.PublicURL,
+		Version:            "0.1.0",
+		Capabilities:       server.AgentCapabilities{Streaming: boolPtr(true)},
+		DefaultInputModes:  []string{"text"},
+		DefaultOutputModes: []string{"text"},
+	}
+
+	middleware := []server.Middleware{
+		telemetry.NewMiddleware(providers),
+	}
+	if cfg.Auth.JWKSURL != "" {
+		authMiddleware, err := authn.NewMiddleware(ctx, cfg.Auth)
+		if err != nil {
+			return err
+		}
+		middleware = append(middleware, authMiddleware)
+	}
+	middleware = append(middleware, ratelimit.NewMiddleware(redisClient, cfg.RateLimit))
+
+	a2aServer, err := server.NewA2AServer(agentCard, taskManager,
+		server.WithMiddleWare(middleware...),
+	)
+	if err != nil {
+		return err
+	}
+
+	errCh := make(chan error, 1)
+	go func() {
+		log.Infof("trpc-reverse: listening on %s, forwarding to %v (strategy=%s)",
+			cfg.ListenAddr, cfg.UpstreamURLs, cfg.UpstreamStrategy)
+		if err := a2aServer.Start(cfg.ListenAddr); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			errCh <- err
+			return
+		}
+		errCh <- nil
+	}()
+
+	select {
+	case <-ctx.Done():
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		return a2aServer.Stop(shutdownCtx)
+	case err := <-errCh:
+		return err
+	}
+}
+
+func boolPtr(b bool) *bool { return &b }
@@ -0,0 +1,108 @@
+// Package authn authenticates incoming A2A requests against a JWKS
+// endpoint before they are forwarded upstream.
+package authn
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/lestrrat-go/jwx/v2/jwk"
+	"github.com/lestrrat-go/jwx/v2/jwt"
+
+	"trpc.group/trpc-go/trpc-a2a-go/auth"
+	"trpc.group/trpc-go/trpc-a2a-go/server"
+
+	"githib.ibm.com/snible/a2a-examples/trpc-reverse/internal/config"
+	"githib.ibm.com/snible/a2a-examples/trpc-reverse/internal/rpcerror"
+)
+
+// Middleware validates the Authorization: Bearer <JWT> header of every
+// request against a background-refreshed JWKS key set, rejecting requests
+// that fail signature, issuer, audience, or expiry checks with a JSON-RPC
+// error envelope instead of forwarding them upstream. On success, the
+// principal is stored in the request context under auth.AuthUserKey — the
+// same key trpc-a2a-go's own auth package uses — so downstream middleware
+// and handlers read it the same way regardless of which auth mechanism
+// populated it.
+type Middleware struct {
+	cfg   config.AuthConfig
+	cache *jwk.Cache
+}
+
+var _ server.Middleware = (*Middleware)(nil)
+
+// NewMiddleware builds a Middleware that validates tokens against
+// cfg.JWKSURL, fetching the key set once up front so startup fails fast on
+// a bad URL rather than on the first request.
+func NewMiddleware(ctx context.Context, cfg config.AuthConfig) (*Middleware, error) {
+	if cfg.JWKSURL == "" {
+		return nil, fmt.Errorf("authn: JWKSURL is required")
+	}
+	if cfg.PrincipalClaim == "" {
+		cfg.PrincipalClaim = "sub"
+	}
+
+	cache := jwk.NewCache(ctx)
+	if err := cache.Register(cfg.JWKSURL); err != nil {
+		return nil, fmt.Errorf("authn: registering JWKS endpoint %q: %w", cfg.JWKSURL, err)
+	}
+	if _, err := cache.Refresh(ctx, cfg.JWKSURL); err != nil {
+		return nil, fmt.Errorf("authn: fetching initial JWKS from %q: %w", cfg.JWKSURL, err)
+	}
+
+	return &Middleware{cfg: cfg, cache: cache}, nil
+}
+
+// Wrap implements server.Middleware.
+func (m *Middleware) Wrap(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id, _, _ := rpcerror.PeekRequest(r)
+
+		user, err := m.authenticate(r)
+		if err != nil {
+			rpcerror.Write(w, id, http.StatusUnauthorized, rpcerror.CodeUnauthorized, "Unauthorized", err.Error(), nil)
+			return
+		}
+
+		ctx := context.WithValue(r.Context(), auth.AuthUserKey, user)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+func (m *Middleware) authenticate(r *http.Request) (*auth.User, error) {
+	header := r.Header.Get(auth.AuthHeaderName)
+	if header == "" {
+		return nil, auth.ErrMissingToken
+	}
+	parts := strings.SplitN(header, " ", 2)
+	if len(parts) != 2 || !strings.EqualFold(parts[0], string(auth.TokenTypeBearer)) {
+		return nil, auth.ErrInvalidAuthHeader
+	}
+
+	set := jwk.NewCachedSet(m.cache, m.cfg.JWKSURL)
+	opts := []jwt.ParseOption{jwt.WithKeySet(set), jwt.WithValidate(true)}
+	if m.cfg.Issuer != "" {
+		opts = append(opts, jwt.WithIssuer(m.cfg.Issuer))
+	}
+	if m.cfg.Audience != "" {
+		opts = append(opts, jwt.WithAudience(m.cfg.Audience))
+	}
+
+	token, err := jwt.ParseString(parts[1], opts...)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", auth.ErrInvalidToken, err)
+	}
+
+	claim, ok := token.Get(m.cfg.PrincipalClaim)
+	if !ok {
+		return nil, fmt.Errorf("token missing principal claim %q", m.cfg.PrincipalClaim)
+	}
+	principal, ok := claim.(string)
+	if !ok || principal == "" {
+		return nil, fmt.Errorf("principal claim %q is not a non-empty string", m.cfg.PrincipalClaim)
+	}
+
+	return &auth.User{ID: principal}, nil
+}
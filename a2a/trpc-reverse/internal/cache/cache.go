@@ -0,0 +1,243 @@
+// Package cache deduplicates concurrent message/send and message/stream
+// calls that share an idempotency key, and replays a cached terminal result
+// for the same key within a configurable TTL so a retried idempotent call
+// doesn't re-run the upstream agent. Coordination (the in-flight claim and
+// waiter wakeup) happens through Redis, so every proxy replica shares one
+// cache and one in-flight leader per key.
+package cache
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"trpc.group/trpc-go/trpc-a2a-go/protocol"
+)
+
+// keyPrefix namespaces cache keys in the shared Redis instance; upstreampool
+// and ratelimit use their own prefixes, so this only needs to avoid
+// colliding with those.
+const keyPrefix = "trpc-reverse:cache:"
+
+// inFlightTTL bounds how long a single upstream call can hold the in-flight
+// claim before it's assumed dead and released for the next caller to retry
+// as a new leader — generous relative to a typical agent call, but short
+// enough that a crashed leader doesn't wedge a key forever.
+const inFlightTTL = 2 * time.Minute
+
+// pollInterval is how often Wait re-checks the cached result directly, as a
+// fallback for a pub/sub notification missed because the waiter subscribed
+// after Store had already published.
+const pollInterval = 250 * time.Millisecond
+
+// idempotencyMetadataKey is the message.Metadata field a client sets to
+// supply its own idempotency key. Without it, KeyFor hashes the message.
+const idempotencyMetadataKey = "idempotencyKey"
+
+// Cache stores terminal A2A results in Redis, keyed by idempotency key, and
+// coordinates a single in-flight call per key across every proxy replica.
+type Cache struct {
+	rdb redis.UniversalClient
+	ttl time.Duration
+}
+
+// New builds a Cache backed by rdb, the same Redis instance the taskmanager
+// and upstream pool use, caching results for ttl.
+func New(rdb redis.UniversalClient, ttl time.Duration) *Cache {
+	return &Cache{rdb: rdb, ttl: ttl}
+}
+
+// entry is the JSON representation of a cached terminal result: the full
+// sequence of streaming events a message/stream call emitted, or the single
+// event wrapping a message/send call's result.
+type entry struct {
+	Events []protocol.StreamingMessageEvent `json:"events"`
+}
+
+// KeyFor returns message's idempotency key: its client-supplied
+// metadata["idempotencyKey"], or a hash of the message payload when none is
+// given.
+func KeyFor(message protocol.Message) string {
+	if key, ok := message.Metadata[idempotencyMetadataKey].(string); ok && key != "" {
+		return key
+	}
+	return hashMessage(message)
+}
+
+// hashMessage hashes only the parts of message a retry would repeat
+// identically: its role and content. MessageID, TaskID, and ContextID are
+// deliberately excluded, since a client retrying the same call generates a
+// fresh MessageID each time — hashing it would make every retry a cache
+// miss, defeating the point of the fallback.
+func hashMessage(message protocol.Message) string {
+	raw, err := json.Marshal(struct {
+		Role  protocol.MessageRole `json:"role"`
+		Parts []protocol.Part      `json:"parts"`
+	}{Role: message.Role, Parts: message.Parts})
+	if err != nil {
+		// Should always be marshalable; fall back to its own ID so the call
+		// still gets a (non-deduplicated) cache key rather than failing
+		// outright.
+		return message.MessageID
+	}
+	sum := sha256.Sum256(raw)
+	return hex.EncodeToString(sum[:])
+}
+
+// Acquire resolves key for an incoming call. If a cached terminal result
+// already exists (or one appears while waiting on another replica's
+// in-flight call), it is returned for replay and leader is false. Otherwise
+// the caller becomes the sole leader for key across every replica and must
+// call Store on success or Release on failure when it's done.
+func (c *Cache) Acquire(ctx context.Context, key string) (events []protocol.StreamingMessageEvent, leader bool, err error) {
+	events, ok, err := c.Lookup(ctx, key)
+	if err != nil {
+		return nil, false, err
+	}
+	if ok {
+		return events, false, nil
+	}
+
+	claimed, err := c.Claim(ctx, key)
+	if err != nil {
+		return nil, false, err
+	}
+	if claimed {
+		return nil, true, nil
+	}
+
+	events, ok, err = c.Wait(ctx, key)
+	if err != nil {
+		return nil, false, err
+	}
+	if ok {
+		return events, false, nil
+	}
+	// The prior leader's call failed without caching a result. Its Release
+	// already cleared the in-flight key, so re-claim it through Claim rather
+	// than assuming leadership outright — otherwise every waiter woken by
+	// the same failure would forward to upstream concurrently instead of
+	// exactly one of them.
+	claimed, err = c.Claim(ctx, key)
+	if err != nil {
+		return nil, false, err
+	}
+	if claimed {
+		return nil, true, nil
+	}
+	// Another waiter won the re-claim race; fall back to waiting on it.
+	return c.Acquire(ctx, key)
+}
+
+// Lookup returns the cached terminal result for key, if present.
+func (c *Cache) Lookup(ctx context.Context, key string) ([]protocol.StreamingMessageEvent, bool, error) {
+	raw, err := c.rdb.Get(ctx, resultKey(key)).Bytes()
+	if err == redis.Nil {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("cache: reading cached result for %s: %w", key, err)
+	}
+	var e entry
+	if err := json.Unmarshal(raw, &e); err != nil {
+		return nil, false, fmt.Errorf("cache: decoding cached result for %s: %w", key, err)
+	}
+	return e.Events, true, nil
+}
+
+// Claim attempts to become the single in-flight leader for key via SET NX
+// PX. Exactly one caller across every replica gets leader=true; everyone
+// else should Wait for that leader's result instead of calling upstream.
+func (c *Cache) Claim(ctx context.Context, key string) (leader bool, err error) {
+	ok, err := c.rdb.SetNX(ctx, inFlightKey(key), "1", inFlightTTL).Result()
+	if err != nil {
+		return false, fmt.Errorf("cache: claiming %s: %w", key, err)
+	}
+	return ok, nil
+}
+
+// Store saves events as key's cached terminal result and wakes any waiters
+// blocked in Wait. On any error the in-flight claim is released (as Release
+// would) rather than left to sit until inFlightTTL, so waiters fall back to
+// an uncached call promptly instead of polling a key whose leader already
+// gave up on caching it.
+func (c *Cache) Store(ctx context.Context, key string, events []protocol.StreamingMessageEvent) (err error) {
+	defer func() {
+		if err != nil {
+			if relErr := c.Release(ctx, key); relErr != nil {
+				err = fmt.Errorf("%w (and releasing claim: %v)", err, relErr)
+			}
+		}
+	}()
+
+	raw, err := json.Marshal(entry{Events: events})
+	if err != nil {
+		return fmt.Errorf("cache: encoding result for %s: %w", key, err)
+	}
+	if err := c.rdb.Set(ctx, resultKey(key), raw, c.ttl).Err(); err != nil {
+		return fmt.Errorf("cache: storing result for %s: %w", key, err)
+	}
+	if err := c.rdb.Publish(ctx, doneChannel(key), "done").Err(); err != nil {
+		return fmt.Errorf("cache: publishing completion for %s: %w", key, err)
+	}
+	if err := c.rdb.Del(ctx, inFlightKey(key)).Err(); err != nil {
+		return fmt.Errorf("cache: clearing in-flight claim for %s: %w", key, err)
+	}
+	return nil
+}
+
+// Release drops key's in-flight claim without caching a result, for a
+// leader whose upstream call failed: only terminal successes are worth
+// replaying. Waiters wake via the same pub/sub notification Store uses,
+// find no cached result, and fall back to calling upstream themselves.
+func (c *Cache) Release(ctx context.Context, key string) error {
+	if err := c.rdb.Del(ctx, inFlightKey(key)).Err(); err != nil {
+		return fmt.Errorf("cache: releasing claim for %s: %w", key, err)
+	}
+	if err := c.rdb.Publish(ctx, doneChannel(key), "failed").Err(); err != nil {
+		return fmt.Errorf("cache: publishing failure for %s: %w", key, err)
+	}
+	return nil
+}
+
+// Wait blocks until key's leader finishes, successfully or not, or ctx is
+// done, then returns the cached result the same way Lookup would — absent
+// if the leader failed.
+func (c *Cache) Wait(ctx context.Context, key string) ([]protocol.StreamingMessageEvent, bool, error) {
+	sub := c.rdb.Subscribe(ctx, doneChannel(key))
+	defer sub.Close()
+
+	// The leader may have already finished between our failed Claim and
+	// this Subscribe, so check once before waiting on the channel.
+	if events, ok, err := c.Lookup(ctx, key); err != nil || ok {
+		return events, ok, err
+	}
+
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil, false, ctx.Err()
+		case <-sub.Channel():
+			return c.Lookup(ctx, key)
+		case <-ticker.C:
+			// Guards against a missed pub/sub notification — e.g. this
+			// waiter subscribed after Store already published — so a
+			// dropped message doesn't block the waiter until ctx's own
+			// deadline instead of the cache's own freshness.
+			if events, ok, err := c.Lookup(ctx, key); err != nil || ok {
+				return events, ok, err
+			}
+		}
+	}
+}
+
+func resultKey(key string) string   { return keyPrefix + "result:" + key }
+func inFlightKey(key string) string { return keyPrefix + "inflight:" + key }
+func doneChannel(key string) string { return keyPrefix + "done:" + key }
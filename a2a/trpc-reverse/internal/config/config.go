@@ -0,0 +1,332 @@
+// Package config loads the reverse-proxy configuration from the
+// environment, or from a YAML file when one is configured. Flat env vars
+// remain the default so the proxy is easy to drive from a container
+// manifest; the YAML file is there for deployments with enough upstreams
+// that an env var list becomes unwieldy.
+package config
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Config is the fully resolved reverse-proxy configuration.
+type Config struct {
+	// ListenAddr is the address the proxy HTTP server binds to.
+	ListenAddr string
+
+	// PublicURL is the address this proxy advertises in its own agent
+	// card, so that clients route message/send, tasks/get, and
+	// tasks/cancel back through the proxy instead of directly to whichever
+	// upstream happens to be listed first. Defaults to ListenAddr under
+	// http://, which is only right for local/dev use; deployments behind a
+	// load balancer or ingress should set PROXY_PUBLIC_URL explicitly.
+	PublicURL string
+
+	// UpstreamURLs are the A2A agents this proxy load-balances across. At
+	// least one is required.
+	UpstreamURLs []string
+
+	// UpstreamStrategy selects how a task without an existing binding is
+	// assigned to one of UpstreamURLs: "round-robin" (default),
+	// "least-outstanding", or "consistent-hash".
+	UpstreamStrategy string
+
+	// BindingTTL is how long a task's upstream binding is kept in Redis.
+	BindingTTL time.Duration
+
+	// HealthCheckInterval is the base interval between agent-card probes
+	// of a healthy upstream.
+	HealthCheckInterval time.Duration
+
+	// RedisAddr is the address of the Redis instance backing the
+	// taskmanager and upstream bindings.
+	RedisAddr     string
+	RedisPassword string
+	RedisDB       int
+
+	// ServiceName identifies this proxy instance in exported telemetry,
+	// read from OTEL_SERVICE_NAME.
+	ServiceName string
+
+	// Auth configures JWT validation against a JWKS endpoint. Left at its
+	// zero value (JWKSURL empty), the proxy runs without authentication and
+	// RateLimit keys on client IP instead of a principal.
+	Auth AuthConfig
+
+	// RateLimit configures the Redis-backed token-bucket rate limiter
+	// applied to every request.
+	RateLimit RateLimitConfig
+
+	// Cache configures the optional result-caching and request-coalescing
+	// layer for idempotent message/send and message/stream calls. Disabled
+	// by default: set PROXY_CACHE_ENABLED=true (or the YAML file's
+	// cache.enabled) to turn it on.
+	Cache CacheConfig
+}
+
+// AuthConfig configures the JWT/JWKS authentication middleware.
+type AuthConfig struct {
+	// JWKSURL is the JWKS endpoint used to validate incoming bearer tokens.
+	JWKSURL string
+
+	// Issuer and Audience, when set, are checked against the token's iss
+	// and aud claims.
+	Issuer   string
+	Audience string
+
+	// PrincipalClaim is the claim used as the request's principal for
+	// downstream use (notably RateLimit's per-principal bucket key).
+	// Defaults to "sub".
+	PrincipalClaim string
+}
+
+// RateLimitConfig configures the token-bucket rate limiter: Default applies
+// to any method without an entry in PerMethod.
+type RateLimitConfig struct {
+	Default   MethodRateLimit
+	PerMethod map[string]MethodRateLimit
+}
+
+// MethodRateLimit is a token bucket: it allows bursts up to Capacity and
+// refills at RefillPerSecond tokens/second.
+type MethodRateLimit struct {
+	Capacity        int
+	RefillPerSecond float64
+}
+
+// CacheConfig configures the result cache: TTL is how long a cached
+// terminal result stays replayable for its idempotency key.
+type CacheConfig struct {
+	Enabled bool
+	TTL     time.Duration
+}
+
+// fileConfig mirrors the subset of Config that can be set from the YAML
+// file named by PROXY_CONFIG_FILE; env vars fill in whatever it omits.
+type fileConfig struct {
+	Upstreams           []string       `yaml:"upstreams"`
+	UpstreamStrategy    string         `yaml:"upstreamStrategy"`
+	BindingTTL          string         `yaml:"bindingTTL"`
+	HealthCheckInterval string         `yaml:"healthCheckInterval"`
+	Auth                fileAuthConfig `yaml:"auth"`
+	RateLimit           fileRateLimit  `yaml:"rateLimit"`
+	Cache               fileCache      `yaml:"cache"`
+}
+
+// fileAuthConfig mirrors AuthConfig; env vars PROXY_AUTH_* take precedence
+// over these fields.
+type fileAuthConfig struct {
+	JWKSURL        string `yaml:"jwksURL"`
+	Issuer         string `yaml:"issuer"`
+	Audience       string `yaml:"audience"`
+	PrincipalClaim string `yaml:"principalClaim"`
+}
+
+// fileRateLimit mirrors RateLimitConfig. PerMethod has no env var
+// equivalent — a per-method map is awkward to express as a flat env var,
+// so overriding individual methods' budgets requires PROXY_CONFIG_FILE.
+type fileRateLimit struct {
+	Capacity        int                        `yaml:"capacity"`
+	RefillPerSecond float64                    `yaml:"refillPerSecond"`
+	PerMethod       map[string]fileMethodLimit `yaml:"perMethod"`
+}
+
+type fileMethodLimit struct {
+	Capacity        int     `yaml:"capacity"`
+	RefillPerSecond float64 `yaml:"refillPerSecond"`
+}
+
+// fileCache mirrors CacheConfig; env vars PROXY_CACHE_* take precedence
+// over these fields.
+type fileCache struct {
+	Enabled bool   `yaml:"enabled"`
+	TTL     string `yaml:"ttl"`
+}
+
+// Load reads configuration from PROXY_CONFIG_FILE (if set) and the
+// environment, applying the defaults a bare-metal dev run needs. Env vars
+// take precedence over the file for any field they set, so an operator can
+// override a single value without editing the shared file.
+func Load() (Config, error) {
+	var fc fileConfig
+	if path := os.Getenv("PROXY_CONFIG_FILE"); path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return Config{}, fmt.Errorf("config: reading %s: %w", path, err)
+		}
+		if err := yaml.Unmarshal(data, &fc); err != nil {
+			return Config{}, fmt.Errorf("config: parsing %s: %w", path, err)
+		}
+	}
+
+	listenAddr := getEnv("PROXY_LISTEN_ADDR", ":8080")
+	cfg := Config{
+		ListenAddr:       listenAddr,
+		PublicURL:        getEnv("PROXY_PUBLIC_URL", defaultPublicURL(listenAddr)),
+		UpstreamURLs:     upstreamURLs(fc),
+		UpstreamStrategy: firstNonEmpty(os.Getenv("PROXY_UPSTREAM_STRATEGY"), fc.UpstreamStrategy, "round-robin"),
+		RedisAddr:        getEnv("PROXY_REDIS_ADDR", "localhost:6379"),
+		RedisPassword:    os.Getenv("PROXY_REDIS_PASSWORD"),
+		ServiceName:      getEnv("OTEL_SERVICE_NAME", "trpc-reverse"),
+	}
+
+	var err error
+	cfg.BindingTTL, err = parseDuration(firstNonEmpty(os.Getenv("PROXY_BINDING_TTL"), fc.BindingTTL), time.Hour)
+	if err != nil {
+		return Config{}, fmt.Errorf("config: PROXY_BINDING_TTL: %w", err)
+	}
+	cfg.HealthCheckInterval, err = parseDuration(firstNonEmpty(os.Getenv("PROXY_HEALTH_CHECK_INTERVAL"), fc.HealthCheckInterval), 10*time.Second)
+	if err != nil {
+		return Config{}, fmt.Errorf("config: PROXY_HEALTH_CHECK_INTERVAL: %w", err)
+	}
+
+	if len(cfg.UpstreamURLs) == 0 {
+		return Config{}, fmt.Errorf("config: at least one upstream URL is required (PROXY_UPSTREAM_URLS, PROXY_UPSTREAM_URL, or PROXY_CONFIG_FILE upstreams)")
+	}
+
+	cfg.Auth = AuthConfig{
+		JWKSURL:        firstNonEmpty(os.Getenv("PROXY_AUTH_JWKS_URL"), fc.Auth.JWKSURL),
+		Issuer:         firstNonEmpty(os.Getenv("PROXY_AUTH_ISSUER"), fc.Auth.Issuer),
+		Audience:       firstNonEmpty(os.Getenv("PROXY_AUTH_AUDIENCE"), fc.Auth.Audience),
+		PrincipalClaim: firstNonEmpty(os.Getenv("PROXY_AUTH_PRINCIPAL_CLAIM"), fc.Auth.PrincipalClaim, "sub"),
+	}
+
+	cfg.RateLimit, err = rateLimitConfig(fc.RateLimit)
+	if err != nil {
+		return Config{}, err
+	}
+
+	cfg.Cache, err = cacheConfig(fc.Cache)
+	if err != nil {
+		return Config{}, err
+	}
+
+	return cfg, nil
+}
+
+// defaultCacheTTL is used when caching is enabled but no TTL is set via
+// PROXY_CACHE_TTL or the YAML file's cache.ttl.
+const defaultCacheTTL = 5 * time.Minute
+
+func cacheConfig(fc fileCache) (CacheConfig, error) {
+	enabled := fc.Enabled
+	if raw := os.Getenv("PROXY_CACHE_ENABLED"); raw != "" {
+		parsed, err := strconv.ParseBool(raw)
+		if err != nil {
+			return CacheConfig{}, fmt.Errorf("config: PROXY_CACHE_ENABLED: %w", err)
+		}
+		enabled = parsed
+	}
+
+	ttl, err := parseDuration(firstNonEmpty(os.Getenv("PROXY_CACHE_TTL"), fc.TTL), defaultCacheTTL)
+	if err != nil {
+		return CacheConfig{}, fmt.Errorf("config: PROXY_CACHE_TTL: %w", err)
+	}
+
+	return CacheConfig{Enabled: enabled, TTL: ttl}, nil
+}
+
+// defaultRateLimit is used for any method that has no explicit override, in
+// PROXY_RATE_LIMIT_CAPACITY/PROXY_RATE_LIMIT_REFILL_PER_SECOND, or in the
+// YAML file's rateLimit.perMethod.
+var defaultRateLimit = MethodRateLimit{Capacity: 100, RefillPerSecond: 10}
+
+// rateLimitConfig resolves RateLimit.Default from env vars (falling back to
+// the YAML file, then defaultRateLimit) and copies PerMethod overrides,
+// which can only come from the YAML file.
+func rateLimitConfig(fc fileRateLimit) (RateLimitConfig, error) {
+	def := defaultRateLimit
+	if fc.Capacity > 0 {
+		def.Capacity = fc.Capacity
+	}
+	if fc.RefillPerSecond > 0 {
+		def.RefillPerSecond = fc.RefillPerSecond
+	}
+	if raw := os.Getenv("PROXY_RATE_LIMIT_CAPACITY"); raw != "" {
+		capacity, err := strconv.Atoi(raw)
+		if err != nil {
+			return RateLimitConfig{}, fmt.Errorf("config: PROXY_RATE_LIMIT_CAPACITY: %w", err)
+		}
+		def.Capacity = capacity
+	}
+	if raw := os.Getenv("PROXY_RATE_LIMIT_REFILL_PER_SECOND"); raw != "" {
+		refill, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return RateLimitConfig{}, fmt.Errorf("config: PROXY_RATE_LIMIT_REFILL_PER_SECOND: %w", err)
+		}
+		def.RefillPerSecond = refill
+	}
+
+	var perMethod map[string]MethodRateLimit
+	if len(fc.PerMethod) > 0 {
+		perMethod = make(map[string]MethodRateLimit, len(fc.PerMethod))
+		for method, limit := range fc.PerMethod {
+			perMethod[method] = MethodRateLimit{Capacity: limit.Capacity, RefillPerSecond: limit.RefillPerSecond}
+		}
+	}
+
+	return RateLimitConfig{Default: def, PerMethod: perMethod}, nil
+}
+
+// upstreamURLs resolves the upstream list from, in order of precedence, the
+// comma-separated PROXY_UPSTREAM_URLS, the single-value PROXY_UPSTREAM_URL
+// kept for backward compatibility, and the YAML file's upstreams list.
+func upstreamURLs(fc fileConfig) []string {
+	if raw := os.Getenv("PROXY_UPSTREAM_URLS"); raw != "" {
+		return splitAndTrim(raw)
+	}
+	if single := os.Getenv("PROXY_UPSTREAM_URL"); single != "" {
+		return []string{single}
+	}
+	return fc.Upstreams
+}
+
+// defaultPublicURL derives a best-effort public URL from a listen address
+// like ":8080" or "0.0.0.0:8080", for the common case of a local/dev run
+// with no reverse proxy or ingress in front of this one.
+func defaultPublicURL(listenAddr string) string {
+	if strings.HasPrefix(listenAddr, ":") {
+		return "http://localhost" + listenAddr
+	}
+	return "http://" + listenAddr
+}
+
+func splitAndTrim(raw string) []string {
+	parts := strings.Split(raw, ",")
+	out := make([]string, 0, len(parts))
+	for _, p := range parts {
+		if p = strings.TrimSpace(p); p != "" {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+func parseDuration(raw string, fallback time.Duration) (time.Duration, error) {
+	if raw == "" {
+		return fallback, nil
+	}
+	return time.ParseDuration(raw)
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+func getEnv(key, fallback string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return fallback
+}
@@ -0,0 +1,344 @@
+// Package proxytask implements taskmanager.MessageProcessor by forwarding
+// every message to an upstream A2A agent selected from an upstreampool.Pool.
+// It is the glue between the reverse proxy's local TaskManager (which owns
+// task/conversation storage) and the real agents doing the work.
+package proxytask
+
+import (
+	"context"
+	"fmt"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"trpc.group/trpc-go/trpc-a2a-go/log"
+	"trpc.group/trpc-go/trpc-a2a-go/protocol"
+	"trpc.group/trpc-go/trpc-a2a-go/taskmanager"
+
+	"githib.ibm.com/snible/a2a-examples/trpc-reverse/internal/cache"
+	"githib.ibm.com/snible/a2a-examples/trpc-reverse/internal/upstreampool"
+)
+
+// Processor forwards messages to an upstream agent chosen by pool, mirroring
+// the upstream's task lifecycle into the local TaskHandler so that tasks/get
+// and tasks/cancel keep working against local storage. When resultCache is
+// non-nil, messages carrying an idempotency key (or hashing identically to
+// one already seen) are deduplicated against it instead of always being
+// forwarded upstream.
+type Processor struct {
+	pool        *upstreampool.Pool
+	resultCache *cache.Cache
+}
+
+var _ taskmanager.MessageProcessor = (*Processor)(nil)
+
+// New builds a Processor that forwards to whichever endpoint pool selects
+// for each task. resultCache is optional; pass nil to forward every message
+// upstream unconditionally.
+func New(pool *upstreampool.Pool, resultCache *cache.Cache) *Processor {
+	return &Processor{pool: pool, resultCache: resultCache}
+}
+
+// ProcessMessage implements taskmanager.MessageProcessor.
+func (p *Processor) ProcessMessage(
+	ctx context.Context,
+	message protocol.Message,
+	options taskmanager.ProcessOptions,
+	handle taskmanager.TaskHandler,
+) (*taskmanager.MessageProcessingResult, error) {
+	var cacheKey string
+	recordToCache := false
+	if p.resultCache != nil {
+		cacheKey = cache.KeyFor(message)
+		events, leader, err := p.resultCache.Acquire(ctx, cacheKey)
+		if err != nil {
+			log.Errorf("proxytask: result cache unavailable for key %s, forwarding uncached: %v", cacheKey, err)
+		} else if events != nil {
+			return p.replayCached(message, options, handle, events)
+		} else {
+			recordToCache = leader
+		}
+	}
+
+	taskID, err := handle.BuildTask(message.TaskID, message.ContextID)
+	if err != nil {
+		if recordToCache {
+			if relErr := p.resultCache.Release(ctx, cacheKey); relErr != nil {
+				log.Errorf("proxytask: releasing cache claim for key %s: %v", cacheKey, relErr)
+			}
+		}
+		return nil, fmt.Errorf("proxytask: building local task: %w", err)
+	}
+	contextID := handle.GetContextID()
+	message.TaskID = &taskID
+	message.ContextID = &contextID
+
+	// Stick to the same upstream for every task sharing this conversation
+	// when one is known; otherwise the new task's own ID seeds selection.
+	bindingKey := contextID
+	if bindingKey == "" {
+		bindingKey = taskID
+	}
+	endpoint, err := p.pool.SelectForTask(ctx, taskID, bindingKey)
+	if err != nil {
+		if recordToCache {
+			if relErr := p.resultCache.Release(ctx, cacheKey); relErr != nil {
+				log.Errorf("proxytask: releasing cache claim for key %s: %v", cacheKey, relErr)
+			}
+		}
+		if updErr := handle.UpdateTaskState(&taskID, protocol.TaskStateFailed, failureMessage(err)); updErr != nil {
+			log.Errorf("proxytask: recording upstream selection failure for task %s: %v", taskID, updErr)
+		}
+		return nil, fmt.Errorf("proxytask: selecting upstream for task %s: %w", taskID, err)
+	}
+
+	params := protocol.SendMessageParams{Message: message}
+
+	if options.Streaming {
+		subscriber, err := handle.SubscribeTask(&taskID)
+		if err != nil {
+			if recordToCache {
+				if relErr := p.resultCache.Release(ctx, cacheKey); relErr != nil {
+					log.Errorf("proxytask: releasing cache claim for key %s: %v", cacheKey, relErr)
+				}
+			}
+			return nil, fmt.Errorf("proxytask: subscribing to task %s: %w", taskID, err)
+		}
+		go p.relayStream(ctx, taskID, endpoint, params, subscriber, handle, cacheKey, recordToCache)
+		return &taskmanager.MessageProcessingResult{StreamingEvents: subscriber}, nil
+	}
+
+	endpoint.Acquire()
+	result, err := endpoint.Client.SendMessage(ctx, params)
+	endpoint.Release()
+	if err != nil {
+		if recordToCache {
+			if relErr := p.resultCache.Release(ctx, cacheKey); relErr != nil {
+				log.Errorf("proxytask: releasing cache claim for key %s: %v", cacheKey, relErr)
+			}
+		}
+		if updErr := handle.UpdateTaskState(&taskID, protocol.TaskStateFailed, failureMessage(err)); updErr != nil {
+			log.Errorf("proxytask: recording upstream failure for task %s: %v", taskID, updErr)
+		}
+		return nil, fmt.Errorf("proxytask: upstream SendMessage: %w", err)
+	}
+
+	if recordToCache {
+		// result.Result is a *protocol.Message or *protocol.Task, both of
+		// which also implement StreamingMessageResult; the assertion only
+		// fails for a result type this package doesn't otherwise support.
+		if streamResult, ok := result.Result.(protocol.StreamingMessageResult); ok {
+			event := protocol.StreamingMessageEvent{Result: streamResult}
+			if err := p.resultCache.Store(ctx, cacheKey, []protocol.StreamingMessageEvent{event}); err != nil {
+				log.Errorf("proxytask: caching result for key %s: %v", cacheKey, err)
+			}
+		} else if err := p.resultCache.Release(ctx, cacheKey); err != nil {
+			log.Errorf("proxytask: releasing cache claim for key %s: %v", cacheKey, err)
+		}
+	}
+
+	if err := p.applyResult(taskID, result.Result, handle); err != nil {
+		return nil, fmt.Errorf("proxytask: applying upstream result to task %s: %w", taskID, err)
+	}
+
+	task, err := handle.GetTask(&taskID)
+	if err != nil {
+		return nil, fmt.Errorf("proxytask: reading back task %s: %w", taskID, err)
+	}
+	return &taskmanager.MessageProcessingResult{Result: task.Task()}, nil
+}
+
+// relayStream forwards the upstream message/stream SSE events into the
+// local TaskSubscriber, updating the local task's state and artifacts along
+// the way so that tasks/get and resubscription reflect upstream progress.
+// Each forwarded chunk is recorded as a span event on the request span. When
+// recordToCache is set, the caller has already claimed cacheKey as this
+// call's idempotency key leader (see Processor.ProcessMessage), so the full
+// event transcript is cached on a clean finish, or the claim released on
+// error, for cache.Cache.Acquire to resolve for the next caller.
+func (p *Processor) relayStream(
+	ctx context.Context,
+	taskID string,
+	endpoint *upstreampool.Endpoint,
+	params protocol.SendMessageParams,
+	subscriber taskmanager.TaskSubscriber,
+	handle taskmanager.TaskHandler,
+	cacheKey string,
+	recordToCache bool,
+) {
+	span := trace.SpanFromContext(ctx)
+
+	endpoint.Acquire()
+	defer endpoint.Release()
+
+	events, err := endpoint.Client.StreamMessage(ctx, params)
+	if err != nil {
+		log.Errorf("proxytask: upstream StreamMessage for task %s: %v", taskID, err)
+		if recordToCache {
+			if relErr := p.resultCache.Release(ctx, cacheKey); relErr != nil {
+				log.Errorf("proxytask: releasing cache claim for key %s: %v", cacheKey, relErr)
+			}
+		}
+		if updErr := handle.UpdateTaskState(&taskID, protocol.TaskStateFailed, failureMessage(err)); updErr != nil {
+			log.Errorf("proxytask: recording upstream failure for task %s: %v", taskID, updErr)
+		}
+		return
+	}
+
+	var recorded []protocol.StreamingMessageEvent
+	var streamErr error
+
+eventLoop:
+	for event := range events {
+		span.AddEvent("a2a.stream.chunk", trace.WithAttributes(
+			attribute.String("a2a.task_id", taskID),
+			attribute.String("a2a.upstream", endpoint.URL),
+			attribute.String("a2a.kind", event.Result.GetKind()),
+		))
+
+		switch v := event.Result.(type) {
+		case *protocol.Message:
+			if err := subscriber.Send(event); err != nil {
+				log.Errorf("proxytask: forwarding message event for task %s: %v", taskID, err)
+				streamErr = err
+				break eventLoop
+			}
+		case *protocol.Task:
+			if err := p.applyResult(taskID, v, handle); err != nil {
+				log.Errorf("proxytask: applying task event for %s: %v", taskID, err)
+				streamErr = err
+				break eventLoop
+			}
+		case *protocol.TaskStatusUpdateEvent:
+			if err := handle.UpdateTaskState(&taskID, v.Status.State, v.Status.Message); err != nil {
+				log.Errorf("proxytask: updating task state for %s: %v", taskID, err)
+				streamErr = err
+				break eventLoop
+			}
+		case *protocol.TaskArtifactUpdateEvent:
+			if err := handle.AddArtifact(&taskID, v.Artifact, v.IsFinal(), false); err != nil {
+				log.Errorf("proxytask: adding artifact for task %s: %v", taskID, err)
+				streamErr = err
+				break eventLoop
+			}
+		}
+
+		if recordToCache {
+			recorded = append(recorded, event)
+		}
+	}
+
+	if !recordToCache {
+		return
+	}
+	if streamErr != nil {
+		if err := p.resultCache.Release(ctx, cacheKey); err != nil {
+			log.Errorf("proxytask: releasing cache claim for key %s: %v", cacheKey, err)
+		}
+		return
+	}
+	if err := p.resultCache.Store(ctx, cacheKey, recorded); err != nil {
+		log.Errorf("proxytask: caching result for key %s: %v", cacheKey, err)
+	}
+}
+
+// replayCached serves message from the cached terminal result events
+// instead of forwarding it upstream: it still builds a local task (so
+// tasks/get behaves the same as for an uncached call) and applies events
+// to it exactly as relayStream would, but starts from the stored transcript
+// rather than a live upstream call.
+func (p *Processor) replayCached(
+	message protocol.Message,
+	options taskmanager.ProcessOptions,
+	handle taskmanager.TaskHandler,
+	events []protocol.StreamingMessageEvent,
+) (*taskmanager.MessageProcessingResult, error) {
+	taskID, err := handle.BuildTask(message.TaskID, message.ContextID)
+	if err != nil {
+		return nil, fmt.Errorf("proxytask: building local task for cached replay: %w", err)
+	}
+
+	if options.Streaming {
+		subscriber, err := handle.SubscribeTask(&taskID)
+		if err != nil {
+			return nil, fmt.Errorf("proxytask: subscribing to task %s: %w", taskID, err)
+		}
+		go func() {
+			for _, event := range events {
+				if err := p.applyEvent(taskID, event, handle, subscriber); err != nil {
+					log.Errorf("proxytask: replaying cached event for task %s: %v", taskID, err)
+					return
+				}
+			}
+		}()
+		return &taskmanager.MessageProcessingResult{StreamingEvents: subscriber}, nil
+	}
+
+	for _, event := range events {
+		if err := p.applyEvent(taskID, event, handle, nil); err != nil {
+			return nil, fmt.Errorf("proxytask: replaying cached event for task %s: %w", taskID, err)
+		}
+	}
+
+	task, err := handle.GetTask(&taskID)
+	if err != nil {
+		return nil, fmt.Errorf("proxytask: reading back replayed task %s: %w", taskID, err)
+	}
+	return &taskmanager.MessageProcessingResult{Result: task.Task()}, nil
+}
+
+// applyEvent applies a single cached event to the local task the same way
+// relayStream applies a live one: Message events go to subscriber when
+// streaming (mirroring a direct message reply), or update task state to
+// completed otherwise; Task, TaskStatusUpdateEvent, and
+// TaskArtifactUpdateEvent events always update local task state.
+func (p *Processor) applyEvent(
+	taskID string,
+	event protocol.StreamingMessageEvent,
+	handle taskmanager.TaskHandler,
+	subscriber taskmanager.TaskSubscriber,
+) error {
+	switch v := event.Result.(type) {
+	case *protocol.Message:
+		if subscriber != nil {
+			return subscriber.Send(event)
+		}
+		return handle.UpdateTaskState(&taskID, protocol.TaskStateCompleted, v)
+	case *protocol.Task:
+		return p.applyResult(taskID, v, handle)
+	case *protocol.TaskStatusUpdateEvent:
+		return handle.UpdateTaskState(&taskID, v.Status.State, v.Status.Message)
+	case *protocol.TaskArtifactUpdateEvent:
+		return handle.AddArtifact(&taskID, v.Artifact, v.IsFinal(), false)
+	default:
+		return fmt.Errorf("unsupported cached event type %T", event.Result)
+	}
+}
+
+// applyResult mirrors a terminal upstream result (Message or Task) into the
+// local task.
+func (p *Processor) applyResult(taskID string, result protocol.UnaryMessageResult, handle taskmanager.TaskHandler) error {
+	switch v := result.(type) {
+	case *protocol.Message:
+		return handle.UpdateTaskState(&taskID, protocol.TaskStateCompleted, v)
+	case *protocol.Task:
+		if err := handle.UpdateTaskState(&taskID, v.Status.State, v.Status.Message); err != nil {
+			return err
+		}
+		for _, artifact := range v.Artifacts {
+			if err := handle.AddArtifact(&taskID, artifact, true, false); err != nil {
+				return err
+			}
+		}
+		return nil
+	default:
+		return fmt.Errorf("unsupported upstream result type %T", result)
+	}
+}
+
+func failureMessage(err error) *protocol.Message {
+	msg := protocol.NewMessage(protocol.MessageRoleAgent, []protocol.Part{
+		protocol.NewTextPart(fmt.Sprintf("upstream request failed: %v", err)),
+	})
+	return &msg
+}
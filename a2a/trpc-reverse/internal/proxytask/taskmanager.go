@@ -0,0 +1,72 @@
+package proxytask
+
+import (
+	"context"
+	"fmt"
+
+	"trpc.group/trpc-go/trpc-a2a-go/log"
+	"trpc.group/trpc-go/trpc-a2a-go/protocol"
+	redistaskmanager "trpc.group/trpc-go/trpc-a2a-go/taskmanager/redis"
+
+	"githib.ibm.com/snible/a2a-examples/trpc-reverse/internal/upstreampool"
+)
+
+// TaskManager wraps a redis taskmanager.TaskManager so that tasks/get and
+// tasks/cancel, once a task is bound to an upstream, are forwarded to that
+// upstream directly rather than served from the local Redis mirror alone.
+// This matters most for tasks/cancel: the embedded TaskManager only knows
+// how to cancel the in-process processing goroutine, not the upstream agent
+// actually doing the work, so without this override a cancel would never
+// reach the upstream. OnSendMessage, OnSendMessageStream, and the push
+// notification methods are promoted unchanged from the embedded TaskManager.
+type TaskManager struct {
+	*redistaskmanager.TaskManager
+	pool *upstreampool.Pool
+}
+
+// NewTaskManager builds a TaskManager that routes tasks/get and
+// tasks/cancel through pool once a task has a binding.
+func NewTaskManager(inner *redistaskmanager.TaskManager, pool *upstreampool.Pool) *TaskManager {
+	return &TaskManager{TaskManager: inner, pool: pool}
+}
+
+// OnGetTask handles tasks/get, preferring the bound upstream's own view of
+// the task so that history accumulated after a proxy restart, or fields the
+// local mirror doesn't track, are still visible.
+func (m *TaskManager) OnGetTask(ctx context.Context, params protocol.TaskQueryParams) (*protocol.Task, error) {
+	endpoint, bound, err := m.pool.Lookup(ctx, params.ID)
+	if err != nil {
+		return nil, fmt.Errorf("proxytask: looking up binding for task %s: %w", params.ID, err)
+	}
+	if !bound {
+		return m.TaskManager.OnGetTask(ctx, params)
+	}
+
+	task, err := endpoint.Client.GetTasks(ctx, params)
+	if err != nil {
+		log.Errorf("proxytask: upstream GetTasks for task %s failed, falling back to local state: %v", params.ID, err)
+		return m.TaskManager.OnGetTask(ctx, params)
+	}
+	return task, nil
+}
+
+// OnCancelTask handles tasks/cancel by forwarding to the bound upstream
+// instead of only canceling the local processing goroutine, which has no
+// way to stop work already accepted by the upstream agent. Because OnGetTask
+// always defers to the upstream for a bound task, there is no need to mirror
+// the resulting state back into local storage.
+func (m *TaskManager) OnCancelTask(ctx context.Context, params protocol.TaskIDParams) (*protocol.Task, error) {
+	endpoint, bound, err := m.pool.Lookup(ctx, params.ID)
+	if err != nil {
+		return nil, fmt.Errorf("proxytask: looking up binding for task %s: %w", params.ID, err)
+	}
+	if !bound {
+		return m.TaskManager.OnCancelTask(ctx, params)
+	}
+
+	task, err := endpoint.Client.CancelTasks(ctx, params)
+	if err != nil {
+		return nil, fmt.Errorf("proxytask: upstream CancelTasks for task %s: %w", params.ID, err)
+	}
+	return task, nil
+}
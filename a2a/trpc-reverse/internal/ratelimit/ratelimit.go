@@ -0,0 +1,160 @@
+// Package ratelimit enforces a per-principal token-bucket request budget,
+// backed by Redis so every proxy replica shares the same quota.
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"trpc.group/trpc-go/trpc-a2a-go/auth"
+	"trpc.group/trpc-go/trpc-a2a-go/server"
+
+	"githib.ibm.com/snible/a2a-examples/trpc-reverse/internal/config"
+	"githib.ibm.com/snible/a2a-examples/trpc-reverse/internal/rpcerror"
+)
+
+// bucketKeyPrefix namespaces rate-limit bucket keys in the shared Redis
+// instance; upstreampool uses its own prefix for task bindings, so this
+// only needs to avoid colliding with that.
+const bucketKeyPrefix = "trpc-reverse:rate-limit:"
+
+// bucketTTL bounds how long an idle bucket's Redis key lives: long enough
+// that a bursty client refilling slowly doesn't lose its accounting between
+// requests, short enough that idle principals don't accumulate forever.
+const bucketTTL = 24 * time.Hour
+
+// Middleware enforces a token-bucket request budget per principal (the
+// auth.User stored in the request context by authn.Middleware, if any; the
+// client IP otherwise), with per-method overrides so e.g. message/stream
+// can have a different budget than message/send. Bucket state lives in
+// Redis, updated atomically via a Lua script, so every proxy replica
+// enforces the same quota.
+type Middleware struct {
+	rdb    redis.UniversalClient
+	cfg    config.RateLimitConfig
+	script *redis.Script
+}
+
+// NewMiddleware builds a Middleware backed by rdb, the same Redis instance
+// the taskmanager and upstream pool use.
+func NewMiddleware(rdb redis.UniversalClient, cfg config.RateLimitConfig) *Middleware {
+	return &Middleware{rdb: rdb, cfg: cfg, script: redis.NewScript(tokenBucketScript)}
+}
+
+var _ server.Middleware = (*Middleware)(nil)
+
+// Wrap implements server.Middleware.
+func (m *Middleware) Wrap(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id, method, _ := rpcerror.PeekRequest(r)
+		limit := m.limitFor(method)
+		principal := principalFor(r)
+
+		allowed, retryAfter, err := m.take(r.Context(), principal, method, limit)
+		if err != nil {
+			// Redis is also what backs the taskmanager and upstream
+			// bindings, so if it's down the request is about to fail
+			// downstream anyway; fail open here rather than turning a
+			// Redis blip into a hard outage for every client.
+			next.ServeHTTP(w, r)
+			return
+		}
+		if !allowed {
+			headers := http.Header{"Retry-After": {strconv.Itoa(retryAfter)}}
+			rpcerror.Write(w, id, http.StatusTooManyRequests, rpcerror.CodeRateLimited,
+				"Too Many Requests", fmt.Sprintf("retry after %ds", retryAfter), headers)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (m *Middleware) limitFor(method string) config.MethodRateLimit {
+	if limit, ok := m.cfg.PerMethod[method]; ok {
+		return limit
+	}
+	return m.cfg.Default
+}
+
+// take attempts to consume one token from principal's bucket for method,
+// returning whether the request is allowed and, if not, how many seconds
+// the caller should wait before retrying.
+func (m *Middleware) take(ctx context.Context, principal, method string, limit config.MethodRateLimit) (allowed bool, retryAfterSeconds int, err error) {
+	key := bucketKeyPrefix + principal + ":" + method
+	nowMillis := time.Now().UnixMilli()
+
+	res, err := m.script.Run(ctx, m.rdb, []string{key},
+		limit.Capacity, limit.RefillPerSecond, nowMillis, int(bucketTTL.Seconds()),
+	).Result()
+	if err != nil {
+		return false, 0, fmt.Errorf("ratelimit: evaluating token bucket for %s: %w", key, err)
+	}
+
+	values, ok := res.([]interface{})
+	if !ok || len(values) != 2 {
+		return false, 0, fmt.Errorf("ratelimit: unexpected script result %#v", res)
+	}
+	allowedFlag, _ := values[0].(int64)
+	retryAfter, _ := values[1].(int64)
+	return allowedFlag == 1, int(retryAfter), nil
+}
+
+// principalFor returns the rate-limit bucket key for r: the principal an
+// authn.Middleware stored in the request context, or the client IP when no
+// principal is present (auth disabled, or the route doesn't require it).
+func principalFor(r *http.Request) string {
+	if user, ok := r.Context().Value(auth.AuthUserKey).(*auth.User); ok && user != nil && user.ID != "" {
+		return "user:" + user.ID
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	return "ip:" + host
+}
+
+// tokenBucketScript atomically refills and consumes one token from the
+// bucket at KEYS[1]. ARGV: capacity, refillPerSecond, nowMillis, ttlSeconds.
+// Returns {allowed (0 or 1), retryAfterSeconds}.
+const tokenBucketScript = `
+local capacity = tonumber(ARGV[1])
+local refill = tonumber(ARGV[2])
+local now = tonumber(ARGV[3])
+local ttl = tonumber(ARGV[4])
+
+local bucket = redis.call("HMGET", KEYS[1], "tokens", "ts")
+local tokens = tonumber(bucket[1])
+local ts = tonumber(bucket[2])
+if tokens == nil then
+	tokens = capacity
+	ts = now
+end
+
+local elapsed = math.max(0, now - ts)
+tokens = math.min(capacity, tokens + (elapsed / 1000.0) * refill)
+
+local allowed = 0
+local retry_after = 0
+if tokens >= 1 then
+	tokens = tokens - 1
+	allowed = 1
+else
+	local deficit = 1 - tokens
+	if refill > 0 then
+		retry_after = math.ceil(deficit / refill)
+	else
+		retry_after = ttl
+	end
+end
+
+redis.call("HMSET", KEYS[1], "tokens", tokens, "ts", now)
+redis.call("EXPIRE", KEYS[1], ttl)
+
+return {allowed, retry_after}
+`
@@ -0,0 +1,91 @@
+// Package rpcerror writes JSON-RPC 2.0 error envelopes for middleware that
+// rejects a request before it reaches the A2A server's own JSON-RPC
+// handling, so a rejected call still gets a response shaped like every
+// other A2A error instead of a bare HTTP status.
+package rpcerror
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+)
+
+// Implementation-defined server error codes, from the -32000 to -32099
+// range JSON-RPC reserves for that purpose. trpc-a2a-go's own JSON-RPC
+// codes (parse error, invalid params, ...) live in an internal package, so
+// these are defined here rather than imported.
+const (
+	CodeUnauthorized = -32001
+	CodeRateLimited  = -32029
+)
+
+type envelope struct {
+	JSONRPC string `json:"jsonrpc"`
+	ID      any    `json:"id"`
+	Error   body   `json:"error"`
+}
+
+type body struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+	Data    string `json:"data,omitempty"`
+}
+
+// Write sends a JSON-RPC error response with the given HTTP status, id
+// (nil if unknown), code, message, and optional data, plus any extra
+// headers (e.g. Retry-After) the caller wants set before the status line.
+func Write(w http.ResponseWriter, id any, httpStatus, code int, message, data string, headers http.Header) {
+	for k, values := range headers {
+		for _, v := range values {
+			w.Header().Add(k, v)
+		}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(httpStatus)
+	_ = json.NewEncoder(w).Encode(envelope{
+		JSONRPC: "2.0",
+		ID:      id,
+		Error:   body{Code: code, Message: message, Data: data},
+	})
+}
+
+type peekedKey struct{}
+
+type peeked struct {
+	id     any
+	method string
+}
+
+// PeekRequest reads r's JSON-RPC id and method without consuming the body
+// for the next handler in the chain: it restores r.Body to a fresh reader
+// over the same bytes before returning. Middleware that needs to know which
+// A2A method a request is calling, or wants to echo the right id in an
+// error response, should use this instead of reading r.Body directly.
+//
+// The result is cached on r's context, so when multiple middlewares in the
+// same chain call PeekRequest on the same request, only the first actually
+// reads and unmarshals the body.
+func PeekRequest(r *http.Request) (id any, method string, err error) {
+	if p, ok := r.Context().Value(peekedKey{}).(peeked); ok {
+		return p.id, p.method, nil
+	}
+
+	raw, err := io.ReadAll(r.Body)
+	if err != nil {
+		return nil, "", err
+	}
+	r.Body = io.NopCloser(bytes.NewReader(raw))
+
+	var parsed struct {
+		ID     any    `json:"id"`
+		Method string `json:"method"`
+	}
+	if err := json.Unmarshal(raw, &parsed); err != nil {
+		return nil, "", err
+	}
+
+	*r = *r.WithContext(context.WithValue(r.Context(), peekedKey{}, peeked{id: parsed.ID, method: parsed.Method}))
+	return parsed.ID, parsed.Method, nil
+}
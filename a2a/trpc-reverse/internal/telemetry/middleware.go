@@ -0,0 +1,95 @@
+package telemetry
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+
+	"trpc.group/trpc-go/trpc-a2a-go/server"
+)
+
+// Middleware instruments the A2A JSON-RPC endpoint with a span and a set of
+// metrics per request: request count, latency, and in-flight count. It
+// implements server.Middleware so it can be passed to
+// server.WithMiddleWare.
+type Middleware struct {
+	providers *Providers
+}
+
+// NewMiddleware builds a Middleware backed by the given Providers.
+func NewMiddleware(providers *Providers) *Middleware {
+	return &Middleware{providers: providers}
+}
+
+var _ server.Middleware = (*Middleware)(nil)
+
+// rpcEnvelope peeks the JSON-RPC method out of the request body without
+// consuming it, so the span name reflects message/send, tasks/get, etc.
+// rather than just "POST /".
+type rpcEnvelope struct {
+	Method string `json:"method"`
+}
+
+// Wrap adds a span and records metrics around the JSON-RPC handler.
+func (m *Middleware) Wrap(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		method := peekMethod(r)
+
+		parentCtx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+		ctx, span := m.providers.Tracer.Start(parentCtx, spanName(method),
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				attribute.String("rpc.system", "jsonrpc"),
+				attribute.String("rpc.method", method),
+			),
+		)
+		defer span.End()
+
+		attrs := metric.WithAttributes(attribute.String("rpc.method", method))
+		m.providers.InFlight.Add(ctx, 1, attrs)
+		defer m.providers.InFlight.Add(ctx, -1, attrs)
+
+		start := time.Now()
+		next.ServeHTTP(w, r.WithContext(ctx))
+		duration := time.Since(start).Seconds()
+
+		m.providers.RequestCount.Add(ctx, 1, attrs)
+		m.providers.RequestDuration.Record(ctx, duration, attrs)
+		span.SetStatus(codes.Ok, "")
+	})
+}
+
+func spanName(method string) string {
+	if method == "" {
+		return "a2a.unknown"
+	}
+	return "a2a." + method
+}
+
+// peekMethod reads the request body to extract the JSON-RPC method, then
+// restores it so downstream handlers can still read it from the start.
+func peekMethod(r *http.Request) string {
+	if r.Body == nil {
+		return ""
+	}
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return ""
+	}
+	r.Body = io.NopCloser(bytes.NewReader(body))
+
+	var envelope rpcEnvelope
+	if err := json.Unmarshal(body, &envelope); err != nil {
+		return ""
+	}
+	return envelope.Method
+}
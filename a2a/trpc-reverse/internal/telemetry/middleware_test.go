@@ -0,0 +1,33 @@
+package telemetry
+
+import (
+	"bytes"
+	"io"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestPeekMethodRestoresBody(t *testing.T) {
+	body := []byte(`{"jsonrpc":"2.0","id":1,"method":"message/send","params":{}}`)
+	req := httptest.NewRequest("POST", "/", bytes.NewReader(body))
+
+	method := peekMethod(req)
+	if method != "message/send" {
+		t.Fatalf("peekMethod() = %q, want %q", method, "message/send")
+	}
+
+	got, err := io.ReadAll(req.Body)
+	if err != nil {
+		t.Fatalf("reading restored body: %v", err)
+	}
+	if !bytes.Equal(got, body) {
+		t.Fatalf("body after peekMethod = %q, want %q", got, body)
+	}
+}
+
+func TestPeekMethodInvalidJSON(t *testing.T) {
+	req := httptest.NewRequest("POST", "/", bytes.NewReader([]byte("not json")))
+	if method := peekMethod(req); method != "" {
+		t.Fatalf("peekMethod() = %q, want empty string for invalid JSON", method)
+	}
+}
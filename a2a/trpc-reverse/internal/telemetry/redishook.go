@@ -0,0 +1,51 @@
+package telemetry
+
+import (
+	"context"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// redisHook records trpc_reverse.redis.op.duration for every command the
+// Redis taskmanager adapter issues, tagged by command name.
+type redisHook struct {
+	providers *Providers
+}
+
+// InstrumentRedis attaches a metrics hook to client so every command the
+// Redis-backed taskmanager issues is timed.
+func InstrumentRedis(client redis.UniversalClient, providers *Providers) {
+	client.AddHook(&redisHook{providers: providers})
+}
+
+var _ redis.Hook = (*redisHook)(nil)
+
+func (h *redisHook) DialHook(next redis.DialHook) redis.DialHook {
+	return next
+}
+
+func (h *redisHook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		start := time.Now()
+		err := next(ctx, cmd)
+		h.record(ctx, cmd.Name(), start)
+		return err
+	}
+}
+
+func (h *redisHook) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		start := time.Now()
+		err := next(ctx, cmds)
+		h.record(ctx, "pipeline", start)
+		return err
+	}
+}
+
+func (h *redisHook) record(ctx context.Context, command string, start time.Time) {
+	h.providers.RedisOpDuration.Record(ctx, time.Since(start).Seconds(),
+		metric.WithAttributes(attribute.String("db.operation", command)))
+}
@@ -0,0 +1,138 @@
+// Package telemetry wires up OpenTelemetry tracing and metrics for the
+// reverse proxy. Exporters are configured entirely through the standard
+// OTEL_EXPORTER_OTLP_* environment variables (see the OTel spec); when
+// OTEL_EXPORTER_OTLP_ENDPOINT is unset, the global tracer/meter providers
+// are left at their no-op defaults so the proxy behaves exactly as it did
+// before this package existed.
+package telemetry
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetricgrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/propagation"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Meter and tracer names used throughout the proxy.
+const instrumentationName = "githib.ibm.com/snible/a2a-examples/trpc-reverse"
+
+// Providers exposes the tracer and meter the rest of the proxy instruments
+// with, plus the instruments shared across the handler and taskmanager.
+type Providers struct {
+	Tracer trace.Tracer
+	Meter  metric.Meter
+
+	RequestCount    metric.Int64Counter
+	RequestDuration metric.Float64Histogram
+	InFlight        metric.Int64UpDownCounter
+	RedisOpDuration metric.Float64Histogram
+}
+
+// Setup configures the global TracerProvider and MeterProvider from
+// OTEL_EXPORTER_OTLP_* environment variables and returns the instruments
+// the proxy uses. If OTEL_EXPORTER_OTLP_ENDPOINT is not set, the global
+// providers are left untouched (no-op), and the returned instruments are
+// correspondingly no-op.
+//
+// The returned shutdown func flushes and closes any exporters that were
+// started; it is always safe to call, even in the no-op case.
+func Setup(ctx context.Context, serviceName string) (*Providers, func(context.Context) error, error) {
+	shutdown := func(context.Context) error { return nil }
+
+	// Always install a W3C tracecontext propagator so traceparent headers
+	// flow to the upstream agent even when export itself is disabled.
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	if os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT") == "" &&
+		os.Getenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT") == "" {
+		return newProviders(), shutdown, nil
+	}
+
+	res, err := resource.New(ctx,
+		resource.WithAttributes(semconv.ServiceName(serviceName)),
+		resource.WithFromEnv(),
+		resource.WithHost(),
+	)
+	if err != nil {
+		return nil, nil, fmt.Errorf("telemetry: building resource: %w", err)
+	}
+
+	traceExporter, err := otlptracegrpc.New(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("telemetry: creating trace exporter: %w", err)
+	}
+	tracerProvider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(traceExporter),
+		sdktrace.WithResource(res),
+	)
+	otel.SetTracerProvider(tracerProvider)
+
+	metricExporter, err := otlpmetricgrpc.New(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("telemetry: creating metric exporter: %w", err)
+	}
+	meterProvider := sdkmetric.NewMeterProvider(
+		sdkmetric.WithReader(sdkmetric.NewPeriodicReader(metricExporter)),
+		sdkmetric.WithResource(res),
+	)
+	otel.SetMeterProvider(meterProvider)
+
+	shutdown = func(ctx context.Context) error {
+		if err := tracerProvider.Shutdown(ctx); err != nil {
+			return fmt.Errorf("telemetry: shutting down tracer provider: %w", err)
+		}
+		if err := meterProvider.Shutdown(ctx); err != nil {
+			return fmt.Errorf("telemetry: shutting down meter provider: %w", err)
+		}
+		return nil
+	}
+
+	return newProviders(), shutdown, nil
+}
+
+// newProviders builds a Providers from whatever global tracer/meter
+// providers are currently installed (real or no-op).
+func newProviders() *Providers {
+	tracer := otel.Tracer(instrumentationName)
+	meter := otel.Meter(instrumentationName)
+
+	requestCount, _ := meter.Int64Counter(
+		"trpc_reverse.requests",
+		metric.WithDescription("Number of A2A JSON-RPC requests handled by the reverse proxy"),
+		metric.WithUnit("{request}"),
+	)
+	requestDuration, _ := meter.Float64Histogram(
+		"trpc_reverse.request.duration",
+		metric.WithDescription("Latency of A2A JSON-RPC requests handled by the reverse proxy"),
+		metric.WithUnit("s"),
+	)
+	inFlight, _ := meter.Int64UpDownCounter(
+		"trpc_reverse.requests.in_flight",
+		metric.WithDescription("Number of A2A JSON-RPC requests currently being handled"),
+		metric.WithUnit("{request}"),
+	)
+	redisOpDuration, _ := meter.Float64Histogram(
+		"trpc_reverse.redis.op.duration",
+		metric.WithDescription("Latency of Redis operations issued by the taskmanager adapter"),
+		metric.WithUnit("s"),
+	)
+
+	return &Providers{
+		Tracer:          tracer,
+		Meter:           meter,
+		RequestCount:    requestCount,
+		RequestDuration: requestDuration,
+		InFlight:        inFlight,
+		RedisOpDuration: redisOpDuration,
+	}
+}
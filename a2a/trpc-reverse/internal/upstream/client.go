@@ -0,0 +1,28 @@
+// Package upstream builds the A2A client the reverse proxy uses to forward
+// requests to the upstream agent.
+package upstream
+
+import (
+	"fmt"
+	"net/http"
+
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+
+	"trpc.group/trpc-go/trpc-a2a-go/client"
+)
+
+// NewClient builds an A2A client targeting agentURL. The client's HTTP
+// transport is wrapped with otelhttp so every upstream call carries a W3C
+// traceparent header derived from the proxy's request span and is itself
+// recorded as a client span.
+func NewClient(agentURL string) (*client.A2AClient, error) {
+	httpClient := &http.Client{
+		Transport: otelhttp.NewTransport(http.DefaultTransport),
+	}
+
+	c, err := client.NewA2AClient(agentURL, client.WithHTTPClient(httpClient))
+	if err != nil {
+		return nil, fmt.Errorf("upstream: creating A2A client for %q: %w", agentURL, err)
+	}
+	return c, nil
+}
@@ -0,0 +1,60 @@
+package upstreampool
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// bindingKeyPrefix namespaces task-binding keys in the shared Redis
+// instance; taskmanager/redis uses its own prefixes for task state, so this
+// only needs to avoid colliding with those.
+const bindingKeyPrefix = "trpc-reverse:upstream-binding:"
+
+// bindTask records, in Redis, which endpoint URL owns taskID, so that every
+// proxy replica routes tasks/get, tasks/cancel, and resubscription for that
+// task to the same upstream. The binding is set only if absent (NX) so a
+// racing concurrent bind from another replica always wins consistently.
+func bindTask(ctx context.Context, rdb redis.UniversalClient, taskID, endpointURL string, ttl time.Duration) (string, error) {
+	key := bindingKeyPrefix + taskID
+	ok, err := rdb.SetNX(ctx, key, endpointURL, ttl).Result()
+	if err != nil {
+		return "", fmt.Errorf("upstreampool: binding task %s: %w", taskID, err)
+	}
+	if ok {
+		return endpointURL, nil
+	}
+
+	// Someone else bound it first; use their binding.
+	bound, err := rdb.Get(ctx, key).Result()
+	if err != nil {
+		return "", fmt.Errorf("upstreampool: reading existing binding for task %s: %w", taskID, err)
+	}
+	return bound, nil
+}
+
+// rebindTask overwrites taskID's binding unconditionally. Unlike bindTask,
+// there is no "first writer wins" race to resolve here: the caller has
+// already determined the existing binding is stale (points at a URL no
+// longer in the pool) or unhealthy, so any replica reaching this path agrees
+// the binding needs replacing.
+func rebindTask(ctx context.Context, rdb redis.UniversalClient, taskID, endpointURL string, ttl time.Duration) error {
+	if err := rdb.Set(ctx, bindingKeyPrefix+taskID, endpointURL, ttl).Err(); err != nil {
+		return fmt.Errorf("upstreampool: rebinding task %s: %w", taskID, err)
+	}
+	return nil
+}
+
+// lookupBinding returns the endpoint URL taskID is bound to, if any.
+func lookupBinding(ctx context.Context, rdb redis.UniversalClient, taskID string) (string, bool, error) {
+	bound, err := rdb.Get(ctx, bindingKeyPrefix+taskID).Result()
+	if err == redis.Nil {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, fmt.Errorf("upstreampool: looking up binding for task %s: %w", taskID, err)
+	}
+	return bound, true, nil
+}
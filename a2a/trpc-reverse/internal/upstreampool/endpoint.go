@@ -0,0 +1,83 @@
+// Package upstreampool selects which upstream A2A agent a request should be
+// forwarded to when the proxy is configured with more than one upstream. It
+// tracks per-endpoint health, binds a task to the endpoint that first
+// accepted it (in Redis, so every proxy replica agrees), and exposes
+// pluggable selection strategies for picking an endpoint for a new task.
+package upstreampool
+
+import (
+	"sync/atomic"
+	"time"
+
+	"trpc.group/trpc-go/trpc-a2a-go/client"
+)
+
+// Endpoint is a single upstream A2A agent plus the health and load state the
+// pool uses to decide whether, and how often, to route to it.
+type Endpoint struct {
+	URL    string
+	Client *client.A2AClient
+
+	healthy          atomic.Bool
+	consecutiveFails atomic.Int64
+	outstanding      atomic.Int64
+	nextProbeAt      atomic.Int64 // unix nanos; read/written only by the health checker
+}
+
+// newEndpoint builds an Endpoint, starting out healthy so it is eligible for
+// selection before the first health probe runs.
+func newEndpoint(url string, c *client.A2AClient) *Endpoint {
+	e := &Endpoint{URL: url, Client: c}
+	e.healthy.Store(true)
+	return e
+}
+
+// Healthy reports whether the endpoint is currently eligible for selection.
+func (e *Endpoint) Healthy() bool {
+	return e.healthy.Load()
+}
+
+// Outstanding returns the number of requests currently in flight to this
+// endpoint, used by the least-outstanding-requests strategy.
+func (e *Endpoint) Outstanding() int64 {
+	return e.outstanding.Load()
+}
+
+// Acquire marks the start of a request to this endpoint; Release marks its
+// end. Callers should always pair them, typically via defer, so the
+// least-outstanding-requests strategy sees an accurate in-flight count.
+func (e *Endpoint) Acquire() { e.outstanding.Add(1) }
+func (e *Endpoint) Release() { e.outstanding.Add(-1) }
+
+// recordProbe updates health state from the outcome of a single health
+// probe, using consecutive failures to decide whether to eject the endpoint
+// and an exponential backoff to decide when to probe it again.
+func (e *Endpoint) recordProbe(err error, baseInterval time.Duration, maxInterval time.Duration) {
+	if err == nil {
+		e.consecutiveFails.Store(0)
+		e.healthy.Store(true)
+		e.nextProbeAt.Store(time.Now().Add(baseInterval).UnixNano())
+		return
+	}
+
+	fails := e.consecutiveFails.Add(1)
+	if fails >= unhealthyThreshold {
+		e.healthy.Store(false)
+	}
+
+	backoff := baseInterval * time.Duration(1<<min(fails, 6))
+	if backoff > maxInterval {
+		backoff = maxInterval
+	}
+	e.nextProbeAt.Store(time.Now().Add(backoff).UnixNano())
+}
+
+// dueForProbe reports whether enough time has passed since the last probe
+// outcome to try again.
+func (e *Endpoint) dueForProbe() bool {
+	return time.Now().UnixNano() >= e.nextProbeAt.Load()
+}
+
+// unhealthyThreshold is the number of consecutive failed health probes
+// before an endpoint is ejected from selection.
+const unhealthyThreshold = 3
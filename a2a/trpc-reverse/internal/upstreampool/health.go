@@ -0,0 +1,74 @@
+package upstreampool
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"trpc.group/trpc-go/trpc-a2a-go/log"
+	"trpc.group/trpc-go/trpc-a2a-go/protocol"
+)
+
+// probeTimeout bounds a single health-check request so a hung upstream
+// can't stall the health-check loop.
+const probeTimeout = 5 * time.Second
+
+var healthCheckClient = &http.Client{Timeout: probeTimeout}
+
+// StartHealthChecks runs a background loop that probes each endpoint's
+// agent card once its backoff allows, updating its health state, until ctx
+// is canceled. Callers typically run this in its own goroutine.
+func (p *Pool) StartHealthChecks(ctx context.Context) {
+	ticker := time.NewTicker(p.healthCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			for _, e := range p.endpoints {
+				if e.dueForProbe() {
+					go p.probe(ctx, e)
+				}
+			}
+		}
+	}
+}
+
+func (p *Pool) probe(ctx context.Context, e *Endpoint) {
+	wasHealthy := e.Healthy()
+
+	ctx, cancel := context.WithTimeout(ctx, probeTimeout)
+	defer cancel()
+
+	err := fetchAgentCard(ctx, e.URL)
+	e.recordProbe(err, p.healthCheckInterval, maxHealthCheckInterval)
+
+	if isHealthy := e.Healthy(); isHealthy != wasHealthy {
+		if isHealthy {
+			log.Infof("upstreampool: endpoint %s recovered", e.URL)
+		} else {
+			log.Errorf("upstreampool: endpoint %s ejected after repeated health-check failures: %v", e.URL, err)
+		}
+	}
+}
+
+func fetchAgentCard(ctx context.Context, endpointURL string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpointURL+protocol.AgentCardPath, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := healthCheckClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %d fetching agent card", resp.StatusCode)
+	}
+	return nil
+}
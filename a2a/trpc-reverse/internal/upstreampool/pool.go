@@ -0,0 +1,159 @@
+package upstreampool
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"trpc.group/trpc-go/trpc-a2a-go/log"
+
+	"githib.ibm.com/snible/a2a-examples/trpc-reverse/internal/upstream"
+)
+
+// Options configures a Pool.
+type Options struct {
+	// Strategy selects an endpoint for a task that isn't bound to one yet.
+	// Defaults to round-robin.
+	Strategy Strategy
+
+	// BindingTTL is how long a task->endpoint binding is kept in Redis.
+	// Defaults to DefaultBindingTTL.
+	BindingTTL time.Duration
+
+	// HealthCheckInterval is the base interval between agent-card probes of
+	// a healthy endpoint. Defaults to DefaultHealthCheckInterval.
+	HealthCheckInterval time.Duration
+}
+
+// DefaultBindingTTL and DefaultHealthCheckInterval are used when the
+// corresponding Options field is left at its zero value.
+const (
+	DefaultBindingTTL          = time.Hour
+	DefaultHealthCheckInterval = 10 * time.Second
+	maxHealthCheckInterval     = 5 * time.Minute
+)
+
+// Pool selects which upstream A2A agent a call should be forwarded to,
+// keeping follow-up calls for the same task on the endpoint that first
+// accepted it.
+type Pool struct {
+	endpoints           []*Endpoint
+	strategy            Strategy
+	rdb                 redis.UniversalClient
+	bindingTTL          time.Duration
+	healthCheckInterval time.Duration
+}
+
+// New builds a Pool forwarding to urls, one A2A client per URL. rdb is the
+// same Redis instance the taskmanager uses, so task->endpoint bindings are
+// shared by every proxy replica.
+func New(urls []string, strategy Strategy, rdb redis.UniversalClient, opts Options) (*Pool, error) {
+	if len(urls) == 0 {
+		return nil, fmt.Errorf("upstreampool: at least one upstream URL is required")
+	}
+	if strategy == nil {
+		strategy = NewRoundRobin()
+	}
+	if opts.BindingTTL <= 0 {
+		opts.BindingTTL = DefaultBindingTTL
+	}
+	if opts.HealthCheckInterval <= 0 {
+		opts.HealthCheckInterval = DefaultHealthCheckInterval
+	}
+
+	endpoints := make([]*Endpoint, 0, len(urls))
+	for _, u := range urls {
+		c, err := upstream.NewClient(u)
+		if err != nil {
+			return nil, fmt.Errorf("upstreampool: building client for %q: %w", u, err)
+		}
+		endpoints = append(endpoints, newEndpoint(u, c))
+	}
+
+	return &Pool{
+		endpoints:           endpoints,
+		strategy:            strategy,
+		rdb:                 rdb,
+		bindingTTL:          opts.BindingTTL,
+		healthCheckInterval: opts.HealthCheckInterval,
+	}, nil
+}
+
+// SelectForTask returns the endpoint taskID should be forwarded to: its
+// existing binding if one exists, or a freshly chosen (and bound) endpoint
+// otherwise. key is the value the selection strategy hashes or load-balances
+// on for a new binding; callers pass the task's contextID when known, since
+// that is stable across the several tasks a single conversation may create.
+func (p *Pool) SelectForTask(ctx context.Context, taskID, key string) (*Endpoint, error) {
+	bound, alreadyBound, err := lookupBinding(ctx, p.rdb, taskID)
+	if err != nil {
+		return nil, err
+	}
+	if alreadyBound {
+		if e := p.byURL(bound); e != nil && e.Healthy() {
+			return e, nil
+		}
+		log.Warnf("upstreampool: task %s bound to unhealthy or unknown endpoint %q, re-selecting", taskID, bound)
+	}
+
+	healthy := p.healthyEndpoints()
+	if len(healthy) == 0 {
+		return nil, fmt.Errorf("upstreampool: no healthy upstream available")
+	}
+	selected := p.strategy.Select(healthy, key)
+	if selected == nil {
+		return nil, fmt.Errorf("upstreampool: strategy selected no endpoint")
+	}
+
+	if alreadyBound {
+		// The existing binding is stale or unhealthy: overwrite it rather
+		// than SetNX, since there's no first-writer-wins race left to
+		// resolve for a task that already has a (bad) binding.
+		if err := rebindTask(ctx, p.rdb, taskID, selected.URL, p.bindingTTL); err != nil {
+			return nil, err
+		}
+		return selected, nil
+	}
+
+	boundURL, err := bindTask(ctx, p.rdb, taskID, selected.URL, p.bindingTTL)
+	if err != nil {
+		return nil, err
+	}
+	if e := p.byURL(boundURL); e != nil {
+		return e, nil
+	}
+	return selected, nil
+}
+
+// Lookup returns the endpoint taskID is already bound to, if any, without
+// selecting a new one. tasks/get and tasks/cancel use this to route directly
+// to the originating upstream.
+func (p *Pool) Lookup(ctx context.Context, taskID string) (*Endpoint, bool, error) {
+	bound, ok, err := lookupBinding(ctx, p.rdb, taskID)
+	if err != nil || !ok {
+		return nil, false, err
+	}
+	e := p.byURL(bound)
+	return e, e != nil, nil
+}
+
+func (p *Pool) byURL(url string) *Endpoint {
+	for _, e := range p.endpoints {
+		if e.URL == url {
+			return e
+		}
+	}
+	return nil
+}
+
+func (p *Pool) healthyEndpoints() []*Endpoint {
+	healthy := make([]*Endpoint, 0, len(p.endpoints))
+	for _, e := range p.endpoints {
+		if e.Healthy() {
+			healthy = append(healthy, e)
+		}
+	}
+	return healthy
+}
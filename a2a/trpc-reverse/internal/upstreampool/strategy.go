@@ -0,0 +1,99 @@
+package upstreampool
+
+import (
+	"fmt"
+	"sync/atomic"
+
+	"github.com/cespare/xxhash/v2"
+	"github.com/dgryski/go-rendezvous"
+)
+
+// Strategy picks one of the healthy endpoints for a new task. key is the
+// value the call should stick to across the task's lifetime (its contextID,
+// or its taskID when no contextID is available yet); stateless strategies
+// ignore it.
+type Strategy interface {
+	Select(healthy []*Endpoint, key string) *Endpoint
+}
+
+// NewStrategy builds the Strategy named by the config value: "round-robin",
+// "least-outstanding", or "consistent-hash".
+func NewStrategy(name string) (Strategy, error) {
+	switch name {
+	case "", "round-robin":
+		return NewRoundRobin(), nil
+	case "least-outstanding":
+		return NewLeastOutstanding(), nil
+	case "consistent-hash":
+		return NewConsistentHash(), nil
+	default:
+		return nil, fmt.Errorf("upstreampool: unknown strategy %q", name)
+	}
+}
+
+// roundRobin cycles through the healthy endpoints in order.
+type roundRobin struct {
+	counter atomic.Uint64
+}
+
+// NewRoundRobin builds a Strategy that distributes tasks evenly across the
+// healthy endpoints in turn.
+func NewRoundRobin() Strategy {
+	return &roundRobin{}
+}
+
+func (s *roundRobin) Select(healthy []*Endpoint, _ string) *Endpoint {
+	if len(healthy) == 0 {
+		return nil
+	}
+	i := s.counter.Add(1) - 1
+	return healthy[i%uint64(len(healthy))]
+}
+
+// leastOutstanding picks the healthy endpoint with the fewest in-flight
+// requests, breaking ties in favor of the first endpoint encountered.
+type leastOutstanding struct{}
+
+// NewLeastOutstanding builds a Strategy that routes each new task to
+// whichever healthy endpoint currently has the fewest requests in flight.
+func NewLeastOutstanding() Strategy {
+	return leastOutstanding{}
+}
+
+func (leastOutstanding) Select(healthy []*Endpoint, _ string) *Endpoint {
+	var best *Endpoint
+	for _, e := range healthy {
+		if best == nil || e.Outstanding() < best.Outstanding() {
+			best = e
+		}
+	}
+	return best
+}
+
+// consistentHash routes by rendezvous (highest random weight) hashing on
+// key, so that as endpoints are added or removed only the tasks hashed to
+// the changed endpoint move. It reuses github.com/dgryski/go-rendezvous,
+// already pulled in transitively by go-redis, rather than adding a new
+// hashing dependency.
+type consistentHash struct{}
+
+// NewConsistentHash builds a Strategy that hashes key to a healthy endpoint,
+// keeping tasks sharing the same key (contextID or taskID) on the same
+// upstream as the healthy set changes.
+func NewConsistentHash() Strategy {
+	return consistentHash{}
+}
+
+func (consistentHash) Select(healthy []*Endpoint, key string) *Endpoint {
+	if len(healthy) == 0 {
+		return nil
+	}
+	urls := make([]string, len(healthy))
+	byURL := make(map[string]*Endpoint, len(healthy))
+	for i, e := range healthy {
+		urls[i] = e.URL
+		byURL[e.URL] = e
+	}
+	r := rendezvous.New(urls, xxhash.Sum64String)
+	return byURL[r.Lookup(key)]
+}
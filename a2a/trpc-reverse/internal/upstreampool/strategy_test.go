@@ -0,0 +1,53 @@
+package upstreampool
+
+import "testing"
+
+func newTestEndpoint(url string) *Endpoint {
+	return newEndpoint(url, nil)
+}
+
+func TestRoundRobinCycles(t *testing.T) {
+	endpoints := []*Endpoint{newTestEndpoint("a"), newTestEndpoint("b"), newTestEndpoint("c")}
+	s := NewRoundRobin()
+
+	var got []string
+	for i := 0; i < 6; i++ {
+		got = append(got, s.Select(endpoints, "").URL)
+	}
+	want := []string{"a", "b", "c", "a", "b", "c"}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("Select()[%d] = %q, want %q (full: %v)", i, got[i], want[i], got)
+		}
+	}
+}
+
+func TestLeastOutstandingPicksFewestInFlight(t *testing.T) {
+	a, b := newTestEndpoint("a"), newTestEndpoint("b")
+	a.Acquire()
+	a.Acquire()
+	b.Acquire()
+
+	s := NewLeastOutstanding()
+	if got := s.Select([]*Endpoint{a, b}, ""); got.URL != "b" {
+		t.Fatalf("Select() = %q, want %q", got.URL, "b")
+	}
+}
+
+func TestConsistentHashIsStableForSameKey(t *testing.T) {
+	endpoints := []*Endpoint{newTestEndpoint("a"), newTestEndpoint("b"), newTestEndpoint("c")}
+	s := NewConsistentHash()
+
+	first := s.Select(endpoints, "task-123")
+	for i := 0; i < 10; i++ {
+		if got := s.Select(endpoints, "task-123"); got.URL != first.URL {
+			t.Fatalf("Select() for same key = %q, want stable %q", got.URL, first.URL)
+		}
+	}
+}
+
+func TestNewStrategyUnknownName(t *testing.T) {
+	if _, err := NewStrategy("nonexistent"); err == nil {
+		t.Fatal("NewStrategy(\"nonexistent\") = nil error, want error")
+	}
+}